@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// silenceChoices are the quick-pick buttons shown on every alert embed.
+var silenceChoices = []struct {
+	Label    string
+	Duration time.Duration
+}{
+	{"Silence 1h", time.Hour},
+	{"Silence 4h", 4 * time.Hour},
+	{"Silence 24h", 24 * time.Hour},
+}
+
+// webhookURLPattern extracts a webhook's ID and token, the two things
+// discordgo's WebhookExecute needs instead of a bare POST URL.
+var webhookURLPattern = regexp.MustCompile(`/api/webhooks/(\d+)/([a-zA-Z0-9_-]+)`)
+
+// Bot runs a discordgo session so alert messages can carry interactive
+// silence/ack buttons. It is optional: DiscordSender posts plain webhook
+// messages when no bot token is configured (see discordBot in sender_discord.go).
+type Bot struct {
+	session         *discordgo.Session
+	alertmanagerURL string
+	labelAllowlist  map[string]bool
+
+	mu     sync.Mutex
+	labels map[string]map[string]string // groupKey -> labels of its first alert
+}
+
+// NewBot connects a discordgo session for botToken and wires up the
+// silence/ack button and custom-duration modal handlers. alertmanagerURL is
+// the base URL of Alertmanager's API (v2) the buttons call into.
+// allowedLabels restricts which alert labels can become a silence matcher,
+// so a careless click can't silence far more than the alert shown.
+func NewBot(botToken, alertmanagerURL string, allowedLabels []string) (*Bot, error) {
+	session, err := discordgo.New("Bot " + botToken)
+	if err != nil {
+		return nil, fmt.Errorf("create discord session: %w", err)
+	}
+	allow := make(map[string]bool, len(allowedLabels))
+	for _, l := range allowedLabels {
+		allow[l] = true
+	}
+	b := &Bot{
+		session:         session,
+		alertmanagerURL: strings.TrimRight(alertmanagerURL, "/"),
+		labelAllowlist:  allow,
+		labels:          map[string]map[string]string{},
+	}
+	session.AddHandler(b.onInteraction)
+	return b, nil
+}
+
+// Start opens the gateway connection. Call before the HTTP listener starts
+// serving so buttons are live as soon as the first alert can arrive.
+func (b *Bot) Start() error {
+	return b.session.Open()
+}
+
+// Stop closes the gateway connection.
+func (b *Bot) Stop() error {
+	return b.session.Close()
+}
+
+// remember keeps the first alert's labels for groupKey around so a later
+// button click can rebuild a silence matcher without re-fetching the alert.
+func (b *Bot) remember(groupKey string, labels map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.labels[groupKey] = labels
+}
+
+// forget drops groupKey's cached labels. Called once a group resolves, since
+// nothing left to silence means the entry no longer serves a button click —
+// without this, labels grows by one entry per distinct groupKey for the life
+// of the process.
+func (b *Bot) forget(groupKey string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.labels, groupKey)
+}
+
+// components builds the silence/ack action row attached to an alert embed.
+// Each custom ID encodes the alert's groupKey so onInteraction can find its
+// labels again via remember.
+func (b *Bot) components(groupKey string) []discordgo.MessageComponent {
+	var buttons []discordgo.MessageComponent
+	for _, sc := range silenceChoices {
+		buttons = append(buttons, discordgo.Button{
+			Label:    sc.Label,
+			Style:    discordgo.PrimaryButton,
+			CustomID: fmt.Sprintf("silence:%s:%s", sc.Duration, groupKey),
+		})
+	}
+	buttons = append(buttons,
+		discordgo.Button{
+			Label:    "Custom",
+			Style:    discordgo.SecondaryButton,
+			CustomID: "silence-custom:" + groupKey,
+		},
+		discordgo.Button{
+			Label:    "Ack",
+			Style:    discordgo.SuccessButton,
+			CustomID: "ack:" + groupKey,
+		},
+	)
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// sendWithComponents posts DO to the Discord webhook identified by whURL,
+// the same as postJSON, but through the bot's session so the message's
+// buttons route their interactions back to this application.
+func (b *Bot) sendWithComponents(whURL string, DO discordOut, groupKey string) error {
+	id, token, err := parseWebhookURL(whURL)
+	if err != nil {
+		return err
+	}
+	embeds := make([]*discordgo.MessageEmbed, len(DO.Embeds))
+	for i, e := range DO.Embeds {
+		fields := make([]*discordgo.MessageEmbedField, len(e.Fields))
+		for j, f := range e.Fields {
+			fields[j] = &discordgo.MessageEmbedField{Name: f.Name, Value: f.Value}
+		}
+		embeds[i] = &discordgo.MessageEmbed{
+			Title:       e.Title,
+			Description: e.Description,
+			Color:       e.Color,
+			Fields:      fields,
+		}
+	}
+	_, err = b.session.WebhookExecute(id, token, false, &discordgo.WebhookParams{
+		Content:    DO.Content,
+		Embeds:     embeds,
+		Components: b.components(groupKey),
+	})
+	return err
+}
+
+func parseWebhookURL(whURL string) (id, token string, err error) {
+	m := webhookURLPattern.FindStringSubmatch(whURL)
+	if m == nil {
+		return "", "", fmt.Errorf("webhook URL %q doesn't look like a Discord webhook URL", whURL)
+	}
+	return m[1], m[2], nil
+}
+
+func (b *Bot) onInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionMessageComponent:
+		b.handleComponent(s, i)
+	case discordgo.InteractionModalSubmit:
+		b.handleModalSubmit(s, i)
+	}
+}
+
+func (b *Bot) handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	switch {
+	case strings.HasPrefix(customID, "silence-custom:"):
+		b.showCustomModal(s, i, strings.TrimPrefix(customID, "silence-custom:"))
+	case strings.HasPrefix(customID, "silence:"):
+		rest := strings.TrimPrefix(customID, "silence:")
+		durationStr, groupKey, ok := strings.Cut(rest, ":")
+		if !ok {
+			return
+		}
+		d, err := time.ParseDuration(durationStr)
+		if err != nil {
+			b.respondError(s, i, fmt.Sprintf("bad duration %q", durationStr))
+			return
+		}
+		b.silence(s, i, groupKey, d, "")
+	case strings.HasPrefix(customID, "ack:"):
+		b.ack(s, i, strings.TrimPrefix(customID, "ack:"))
+	}
+}
+
+func (b *Bot) showCustomModal(s *discordgo.Session, i *discordgo.InteractionCreate, groupKey string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "silence-modal:" + groupKey,
+			Title:    "Silence this alert",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "duration",
+						Label:       "Duration (e.g. 30m, 2h, 3h30m)",
+						Style:       discordgo.TextInputShort,
+						Required:    true,
+						Placeholder: "2h",
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID: "comment",
+						Label:    "Comment",
+						Style:    discordgo.TextInputParagraph,
+						Required: false,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("discord: failed to open silence modal: %v", err)
+	}
+}
+
+func (b *Bot) handleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.ModalSubmitData().CustomID
+	if !strings.HasPrefix(customID, "silence-modal:") {
+		return
+	}
+	groupKey := strings.TrimPrefix(customID, "silence-modal:")
+
+	var durationStr, comment string
+	for _, row := range i.ModalSubmitData().Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok || len(actionsRow.Components) == 0 {
+			continue
+		}
+		input, ok := actionsRow.Components[0].(*discordgo.TextInput)
+		if !ok {
+			continue
+		}
+		switch input.CustomID {
+		case "duration":
+			durationStr = input.Value
+		case "comment":
+			comment = input.Value
+		}
+	}
+
+	d, err := time.ParseDuration(durationStr)
+	if err != nil {
+		b.respondError(s, i, fmt.Sprintf("bad duration %q", durationStr))
+		return
+	}
+	b.silence(s, i, groupKey, d, comment)
+}
+
+// silenceMatcher is the Alertmanager v2 API's matcher shape for POST /api/v2/silences.
+type silenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+type silenceRequest struct {
+	Matchers  []silenceMatcher `json:"matchers"`
+	StartsAt  string           `json:"startsAt"`
+	EndsAt    string           `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+}
+
+func (b *Bot) silence(s *discordgo.Session, i *discordgo.InteractionCreate, groupKey string, d time.Duration, comment string) {
+	b.mu.Lock()
+	labels := b.labels[groupKey]
+	b.mu.Unlock()
+
+	var matchers []silenceMatcher
+	for name, value := range labels {
+		if !b.labelAllowlist[name] {
+			continue
+		}
+		matchers = append(matchers, silenceMatcher{Name: name, Value: value, IsEqual: true})
+	}
+	if len(matchers) == 0 {
+		b.respondError(s, i, "no allowlisted labels found for this alert; nothing was silenced")
+		return
+	}
+
+	now := time.Now()
+	who := "unknown"
+	if i.Member != nil && i.Member.User != nil {
+		who = i.Member.User.Username
+	}
+	req := silenceRequest{
+		Matchers:  matchers,
+		StartsAt:  now.Format(time.RFC3339),
+		EndsAt:    now.Add(d).Format(time.RFC3339),
+		CreatedBy: who,
+		Comment:   comment,
+	}
+	if err := b.postSilence(req); err != nil {
+		b.respondError(s, i, fmt.Sprintf("failed to create silence: %v", err))
+		return
+	}
+
+	b.updateMessage(s, i, fmt.Sprintf("🔇 Silenced by **%s** until %s", who, now.Add(d).Format(time.RFC822)))
+}
+
+func (b *Bot) ack(s *discordgo.Session, i *discordgo.InteractionCreate, groupKey string) {
+	who := "unknown"
+	if i.Member != nil && i.Member.User != nil {
+		who = i.Member.User.Username
+	}
+	b.updateMessage(s, i, fmt.Sprintf("✅ Acknowledged by **%s**", who))
+}
+
+// updateMessage appends note to the original message's content and
+// re-renders it in place, so every viewer sees who silenced/acked it.
+func (b *Bot) updateMessage(s *discordgo.Session, i *discordgo.InteractionCreate, note string) {
+	content := note
+	if i.Message != nil && i.Message.Content != "" {
+		content = i.Message.Content + "\n" + note
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+	if err != nil {
+		log.Printf("discord: failed to update message: %v", err)
+	}
+}
+
+func (b *Bot) respondError(s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: msg,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("discord: failed to send error response: %v", err)
+	}
+}
+
+func (b *Bot) postSilence(req silenceRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	r, err := http.Post(b.alertmanagerURL+"/api/v2/silences", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode >= http.StatusBadRequest {
+		b, _ := ioutil.ReadAll(r.Body)
+		return fmt.Errorf("alertmanager returned %d: %s", r.StatusCode, string(b))
+	}
+	return nil
+}
+
+// parseLabelAllowlist splits a comma-separated -discord.silence-labels flag
+// value into a slice, trimming whitespace and dropping empty entries.
+func parseLabelAllowlist(s string) []string {
+	var labels []string
+	for _, l := range strings.Split(s, ",") {
+		l = strings.TrimSpace(l)
+		if l != "" {
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// webhookURLList implements flag.Value for repeatable -webhook.url flags.
+// Each value is "<scheme>=<url>", e.g. "discord=https://discord.com/api/webhooks/...".
+type webhookURLList []SinkConfig
+
+func (w *webhookURLList) String() string {
+	urls := make([]string, len(*w))
+	for i, sc := range *w {
+		urls[i] = sc.Scheme + "=" + sc.URL
+	}
+	return strings.Join(urls, ",")
+}
+
+func (w *webhookURLList) Set(value string) error {
+	scheme, rawURL, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("webhook.url must be scheme=url, got %q", value)
+	}
+	*w = append(*w, SinkConfig{Scheme: scheme, URL: rawURL})
+	return nil
+}
+
+// sinkFileConfig is the shape of the -config.file JSON or YAML document.
+type sinkFileConfig struct {
+	Sinks []SinkConfig `json:"sinks" yaml:"sinks"`
+	// ReceiverTemplates maps an Alertmanager receiver name to the Discord
+	// template set (a subdirectory of -templates.dir) it should render with.
+	ReceiverTemplates map[string]string `json:"receiverTemplates,omitempty" yaml:"receiverTemplates,omitempty"`
+}
+
+// loadSinkConfigFile reads repeatable per-sink filters from a JSON or YAML
+// config file, chosen by path's extension (.yaml/.yml for YAML, JSON
+// otherwise). An empty path is not an error; it just contributes no sinks.
+func loadSinkConfigFile(path string) (sinkFileConfig, error) {
+	if path == "" {
+		return sinkFileConfig{}, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return sinkFileConfig{}, fmt.Errorf("read config file: %w", err)
+	}
+	var cfg sinkFileConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &cfg)
+	default:
+		err = json.Unmarshal(b, &cfg)
+	}
+	if err != nil {
+		return sinkFileConfig{}, fmt.Errorf("parse config file: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadSinks merges sinks from -config.file and repeatable -webhook.url flags
+// with the legacy DISCORD_WEBHOOK env var, so upgrading doesn't silently
+// drop an existing single-webhook setup. It also returns the config file's
+// receiver-to-template-set mapping, if any.
+func loadSinks(configFile string, flagSinks []SinkConfig, legacyDiscordWebhook string) ([]SinkConfig, map[string]string, error) {
+	cfg, err := loadSinkConfigFile(configFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	sinks := append(cfg.Sinks, flagSinks...)
+	if legacyDiscordWebhook != "" {
+		sinks = append(sinks, SinkConfig{Scheme: "discord", URL: legacyDiscordWebhook})
+	}
+	return sinks, cfg.ReceiverTemplates, nil
+}
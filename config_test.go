@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadSinkConfigFileJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sinks.json")
+	writeFile(t, path, `{"sinks":[{"scheme":"discord","url":"https://example.invalid/a"}]}`)
+
+	cfg, err := loadSinkConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadSinkConfigFile: %v", err)
+	}
+	want := []SinkConfig{{Scheme: "discord", URL: "https://example.invalid/a"}}
+	if !reflect.DeepEqual(cfg.Sinks, want) {
+		t.Fatalf("Sinks = %+v, want %+v", cfg.Sinks, want)
+	}
+}
+
+func TestLoadSinkConfigFileYAML(t *testing.T) {
+	for _, ext := range []string{".yaml", ".yml"} {
+		path := filepath.Join(t.TempDir(), "sinks"+ext)
+		writeFile(t, path, "sinks:\n  - scheme: slack\n    url: https://example.invalid/b\n    receiver: critical\nreceiverTemplates:\n  critical: default\n")
+
+		cfg, err := loadSinkConfigFile(path)
+		if err != nil {
+			t.Fatalf("loadSinkConfigFile(%s): %v", ext, err)
+		}
+		want := []SinkConfig{{Scheme: "slack", URL: "https://example.invalid/b", Receiver: "critical"}}
+		if !reflect.DeepEqual(cfg.Sinks, want) {
+			t.Fatalf("Sinks = %+v, want %+v", cfg.Sinks, want)
+		}
+		if cfg.ReceiverTemplates["critical"] != "default" {
+			t.Fatalf("ReceiverTemplates = %+v, want critical=default", cfg.ReceiverTemplates)
+		}
+	}
+}
+
+func TestLoadSinkConfigFileEmptyPath(t *testing.T) {
+	cfg, err := loadSinkConfigFile("")
+	if err != nil {
+		t.Fatalf("loadSinkConfigFile(\"\"): %v", err)
+	}
+	if len(cfg.Sinks) != 0 {
+		t.Fatalf("Sinks = %+v, want none for an empty path", cfg.Sinks)
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
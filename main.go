@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -11,8 +10,6 @@ import (
 	"net/url"
 	"os"
 	"regexp"
-	"strconv"
-	"strings"
 	"time"
 )
 
@@ -56,152 +53,46 @@ type alertManOut struct {
 	Version  string `json:"version"`
 }
 
-type discordOut struct {
-	Content string         `json:"content"`
-	Embeds  []discordEmbed `json:"embeds"`
-}
-
-type discordEmbed struct {
-	Title       string              `json:"title"`
-	Description string              `json:"description"`
-	Color       int                 `json:"color"`
-	Fields      []discordEmbedField `json:"fields"`
-}
-
-type discordEmbedField struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
-}
-
 const defaultListenAddress = "127.0.0.1:9094"
 
 var (
-	whURL         = flag.String("webhook.url", os.Getenv("DISCORD_WEBHOOK"), "Discord WebHook URL.")
+	webhookURLs   webhookURLList
+	configFile    = flag.String("config.file", os.Getenv("CONFIG_FILE"), "Path to a JSON or YAML sink configuration file. A .yaml/.yml extension selects YAML; anything else is parsed as JSON.")
+	templatesDir  = flag.String("templates.dir", os.Getenv("TEMPLATES_DIR"), "Directory of Discord message template sets; one subdirectory per named set. Omit to use the built-in layout.")
 	listenAddress = flag.String("listen.address", os.Getenv("LISTEN_ADDRESS"), "Address:Port to listen on.")
 	debug         = flag.Bool("debug", os.Getenv("DEBUG") == "1", "Enable debug mode.")
+
+	discordBotToken      = flag.String("discord.bot-token", os.Getenv("DISCORD_BOT_TOKEN"), "Discord bot token. When set, alert embeds get silence/ack buttons backed by this bot.")
+	alertmanagerURL      = flag.String("discord.alertmanager-url", os.Getenv("ALERTMANAGER_URL"), "Base URL of the Alertmanager API the silence buttons call. Required with -discord.bot-token.")
+	discordSilenceLabels = flag.String("discord.silence-labels", os.Getenv("DISCORD_SILENCE_LABELS"), "Comma-separated label names allowed to become silence matchers, e.g. \"alertname,instance\".")
+
+	queueMaxDepth   = flag.Int("queue.max-depth", 100, "Maximum number of payloads a per-webhook delivery queue holds before dropping the oldest.")
+	queueMaxRetries = flag.Int("queue.max-retries", 5, "Maximum retries for a 5xx/429 webhook response before a payload is dropped.")
+	queuePersistDir = flag.String("queue.persist-dir", os.Getenv("QUEUE_PERSIST_DIR"), "Directory to persist queued payloads to, so a restart doesn't lose them. Omit to keep queues in memory only.")
+
+	grafanaURLTemplate = flag.String("discord.grafana-url-template", os.Getenv("GRAFANA_URL_TEMPLATE"), "fmt.Sprintf template with two %s verbs (dashboard, panel) for linking an embed to its Grafana panel, e.g. \"https://grafana.example.com/d/%s?viewPanel=%s\". Used when an alert carries grafana_dashboard/grafana_panel labels; falls back to the alert's generatorURL otherwise.")
+	severityIcons      = flag.String("discord.severity-icons", os.Getenv("DISCORD_SEVERITY_ICONS"), "Comma-separated severity=iconURL pairs for the embed thumbnail, e.g. \"critical=https://example.com/critical.png,warning=...,resolved=...\". Omit to send no thumbnail.")
+	embedMaxFields     = flag.Int("embed.max-fields", 25, "Maximum label fields per Discord embed; the remainder is collapsed into a single overflow field to stay under Discord's 25-field embed limit.")
 )
 
-func checkWhURL(whURL string) {
+func init() {
+	flag.Var(&webhookURLs, "webhook.url", "Repeatable scheme=URL webhook target, e.g. discord=https://discord.com/api/webhooks/.... Schemes: discord, slack, teams, mattermost, dingtalk, feishu, wecom.")
+}
+
+func checkWhURL(whURL string) error {
 	if whURL == "" {
-		log.Fatalf("Environment variable 'DISCORD_WEBHOOK' or CLI parameter 'webhook.url' not found.")
+		return fmt.Errorf("environment variable 'DISCORD_WEBHOOK' or CLI parameter 'webhook.url' not found")
 	}
 	_, err := url.Parse(whURL)
 	if err != nil {
-		log.Fatalf("The Discord WebHook URL doesn't seem to be a valid URL.")
+		return fmt.Errorf("the Discord WebHook URL doesn't seem to be a valid URL: %w", err)
 	}
 
 	re := regexp.MustCompile(`https://discord(?:app)?.com/api/webhooks/[0-9]{18,19}/[a-zA-Z0-9_-]+`)
 	if ok := re.Match([]byte(whURL)); !ok {
 		log.Printf("The Discord WebHook URL doesn't seem to be valid.")
 	}
-}
-
-func sendWebhook(amo *alertManOut) {
-	DO := discordOut{
-		Content: fmt.Sprintf("=== Alert: %s - %s ===", amo.Receiver, amo.GroupLabels.Alertname),
-		Embeds:  []discordEmbed{},
-	}
-	var embeds []discordEmbed
-	for _, alert := range amo.Alerts {
-		status := alert.Status
-		embed := discordEmbed{
-			Color:  ColorGrey,
-			Fields: []discordEmbedField{},
-		}
-		switch alert.Status {
-		case "firing":
-			if s, ok := alert.Labels["severity"]; ok {
-				status = s
-			}
-			embed.Color = getSeverityColor(status)
-			break
-		case "resolved":
-			embed.Color = ColorGreen
-			status = "normal"
-			break
-		}
-		loc, _ := time.LoadLocation(os.Getenv("TZ"))
-		startAt, _ := time.Parse(time.RFC3339, alert.StartsAt)
-		startAt = startAt.In(loc)
-		endAt, _ := time.Parse(time.RFC3339, alert.EndsAt)
-		endAt = endAt.In(loc)
-		embed.Title = fmt.Sprintf("[%s] %s", strings.ToUpper(status), alert.Annotations.Summary)
-		var labels []string
-		var metricsValue float64
-		var metricsConv string
-		for k, v := range alert.Labels {
-			if strings.HasPrefix(k, "metrics_") {
-				// ignore metrics
-				if k == "metrics_value" {
-					metricsValue, _ = strconv.ParseFloat(v, 64)
-				} else if k == "metrics_conv" {
-					metricsConv = v
-				}
-				continue
-			}
-			// Ignore some key
-			if k == "value" {
-				continue
-			}
-			labels = append(labels, fmt.Sprintf(": - **_%s:_** %s", k, v))
-		}
-		if status != "normal" {
-			labels = append(labels, fmt.Sprintf(": - **_value:_** %s", valueConv(metricsValue, metricsConv)))
-		}
-		description := strings.Split(alert.Annotations.Description, "\n")
-		for i, v := range description {
-			description[i] = fmt.Sprintf(": - %s", v)
-		}
-		var embedDescribe []string
-		eventTime := startAt
-		if endAt.After(startAt) {
-			eventTime = endAt
-		}
-		embedDescribe = append(embedDescribe, fmt.Sprintf("**⏰ Event Time:** %s", eventTime.Format(time.DateTime)))
-		embedDescribe = append(embedDescribe, fmt.Sprintf("**🏷️ Alert labels:**\n%s", strings.Join(labels, "\n")))
-		// Abnormal state
-		embedDescribe = append(embedDescribe, "------")
-		embedDescribe = append(embedDescribe, fmt.Sprintf("**📖 Description:**\n%s", strings.Join(description, "\n")))
-		if endAt.After(startAt) {
-			embedDescribe = append(embedDescribe, "------")
-			embedDescribe = append(embedDescribe, fmt.Sprintf("**⏲️ Duration:** %s", endAt.Sub(startAt).String()))
-			embedDescribe = append(embedDescribe, fmt.Sprintf(": - **_Start:_** %s", startAt.Format(time.DateTime)))
-			embedDescribe = append(embedDescribe, fmt.Sprintf(": - **_End:_** %s", endAt.Format(time.DateTime)))
-		}
-		embed.Description = strings.Join(embedDescribe, "\n")
-		embeds = append(embeds, embed)
-	}
-
-	if len(embeds) > MaxDiscordEmbed {
-		// Set bulk send
-		for i := 0; i < len(embeds); i += MaxDiscordEmbed {
-			if i+MaxDiscordEmbed <= len(embeds) {
-				DO.Embeds = embeds[i : i+MaxDiscordEmbed]
-			} else {
-				DO.Embeds = embeds[i:]
-			}
-			fireMessageOut(DO)
-		}
-	} else {
-		DO.Embeds = embeds
-		fireMessageOut(DO)
-	}
-}
-
-func fireMessageOut(msg discordOut) {
-	DOD, _ := json.Marshal(msg)
-	if *debug {
-		fmt.Println("Send webhook:", string(DOD))
-	}
-	r, err := http.Post(*whURL, "application/json", bytes.NewReader(DOD))
-	if err != nil {
-		log.Println("Send discord error -:", err)
-		return
-	}
-	if r.StatusCode >= http.StatusBadRequest {
-		b, _ := ioutil.ReadAll(r.Body)
-		log.Println("Discord server return error -:", r.StatusCode, string(b))
-	}
+	return nil
 }
 
 func valueConv(v float64, conv string) string {
@@ -237,6 +128,24 @@ func getSeverityColor(severity string) int {
 	}
 }
 
+// isRawPromAlert reports whether b looks like a raw Prometheus payload (e.g.
+// posted straight from Prometheus's /api/v1/alerts, or a rule webhook
+// pointed here by mistake) rather than an Alertmanager webhook notification.
+// Alertmanager's webhook always sets "version"; Prometheus's own API
+// responses instead wrap the body in {"status": ..., "data": ...}.
+func isRawPromAlert(b []byte) bool {
+	var generic map[string]json.RawMessage
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return false
+	}
+	if _, hasVersion := generic["version"]; hasVersion {
+		return false
+	}
+	_, hasStatus := generic["status"]
+	_, hasData := generic["data"]
+	return hasStatus && hasData
+}
+
 func sendRawPromAlertWarn() {
 	badString := `This program is suppose to be fed by alertmanager.` + "\n" +
 		`It is not a replacement for alertmanager, it is a ` + "\n" +
@@ -247,63 +156,88 @@ func sendRawPromAlertWarn() {
 	log.Print(`/!\ -- You have misconfigured this software -- /!\`)
 	log.Print(`--- --                                      -- ---`)
 	log.Print(badString)
-
-	DO := discordOut{
-		Content: "",
-		Embeds: []discordEmbed{
-			{
-				Title:       "You have misconfigured this software",
-				Description: badString,
-				Color:       ColorGrey,
-				Fields:      []discordEmbedField{},
-			},
-		},
-	}
-
-	DOD, _ := json.Marshal(DO)
-	http.Post(*whURL, "application/json", bytes.NewReader(DOD))
 }
 
 func main() {
 	flag.Parse()
-	checkWhURL(*whURL)
+
+	sinks, receiverTemplates, err := loadSinks(*configFile, webhookURLs, os.Getenv("DISCORD_WEBHOOK"))
+	if err != nil {
+		log.Fatalf("Failed to load sink configuration: %v", err)
+	}
+	if len(sinks) == 0 {
+		log.Fatalf("No sinks configured: pass -webhook.url, -config.file, or DISCORD_WEBHOOK.")
+	}
+	receiverTemplateSets = receiverTemplates
+	if discordTemplateSets, err = LoadTemplateSets(*templatesDir); err != nil {
+		log.Fatalf("Failed to load templates: %v", err)
+	}
+	queueConfig.maxDepth = *queueMaxDepth
+	queueConfig.maxRetries = *queueMaxRetries
+	queueConfig.persistDir = *queuePersistDir
+	discordGrafanaURLTemplate = *grafanaURLTemplate
+	discordSeverityIcons = parseSeverityIcons(*severityIcons)
+	discordEmbedMaxFields = *embedMaxFields
+	registry, err := NewRegistry(sinks)
+	if err != nil {
+		log.Fatalf("Failed to configure sinks: %v", err)
+	}
+
+	if *discordBotToken != "" {
+		if *alertmanagerURL == "" {
+			log.Fatalf("-discord.alertmanager-url is required when -discord.bot-token is set.")
+		}
+		bot, err := NewBot(*discordBotToken, *alertmanagerURL, parseLabelAllowlist(*discordSilenceLabels))
+		if err != nil {
+			log.Fatalf("Failed to configure Discord bot: %v", err)
+		}
+		if err := bot.Start(); err != nil {
+			log.Fatalf("Failed to start Discord bot: %v", err)
+		}
+		defer bot.Stop()
+		discordBot = bot
+		log.Print("Discord bot session started; alert embeds will include silence/ack buttons.")
+	}
 
 	if *listenAddress == "" {
 		*listenAddress = defaultListenAddress
 	}
 
-	log.Printf("Listening on: %s", *listenAddress)
-	log.Fatalf("Failed to listen on HTTP: %v",
-		http.ListenAndServe(*listenAddress, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("%s - [%s] %s", r.Host, r.Method, r.URL)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", metricsHandler)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("%s - [%s] %s", r.Host, r.Method, r.URL)
 
-			b, err := ioutil.ReadAll(r.Body)
-			if err != nil {
-				panic(err)
-			}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+
+		if *debug {
+			fmt.Println("Receive webhook:", string(b))
+		}
 
-			if *debug {
-				fmt.Println("Receive webhook:", string(b))
+		amo := alertManOut{}
+		err = json.Unmarshal(b, &amo)
+		if err != nil {
+			if isRawPromAlert(b) {
+				sendRawPromAlertWarn()
+				return
 			}
 
-			amo := alertManOut{}
-			err = json.Unmarshal(b, &amo)
-			if err != nil {
-				if isRawPromAlert(b) {
-					sendRawPromAlertWarn()
-					return
-				}
+			if len(b) > 1024 {
+				log.Printf("Failed to unpack inbound alert request - %s...", string(b[:1023]))
 
-				if len(b) > 1024 {
-					log.Printf("Failed to unpack inbound alert request - %s...", string(b[:1023]))
+			} else {
+				log.Printf("Failed to unpack inbound alert request - %s", string(b))
+			}
 
-				} else {
-					log.Printf("Failed to unpack inbound alert request - %s", string(b))
-				}
+			return
+		}
 
-				return
-			}
+		registry.Dispatch(r.Context(), &amo)
+	})
 
-			sendWebhook(&amo)
-		})))
+	log.Printf("Listening on: %s", *listenAddress)
+	log.Fatalf("Failed to listen on HTTP: %v", http.ListenAndServe(*listenAddress, mux))
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+)
+
+// metricsHandler renders queue depth/drop/retry/last-error gauges for every
+// live DeliveryQueue in Prometheus text exposition format. Nothing in this
+// tree vendors the Prometheus client library, so the format is written by
+// hand; it's a small, stable enough surface that this is simpler than
+// adding a dependency for it.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	queuesMu.Lock()
+	names := make([]string, 0, len(queues))
+	for name := range queues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	snapshot := make([]*DeliveryQueue, 0, len(names))
+	for _, name := range names {
+		snapshot = append(snapshot, queues[name])
+	}
+	queuesMu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP alertmanager_discord_queue_depth Payloads waiting to send.")
+	fmt.Fprintln(w, "# TYPE alertmanager_discord_queue_depth gauge")
+	for _, q := range snapshot {
+		fmt.Fprintf(w, "alertmanager_discord_queue_depth{webhook=%q} %d\n", q.label(), atomic.LoadInt64(&q.depth))
+	}
+
+	fmt.Fprintln(w, "# HELP alertmanager_discord_queue_dropped_total Payloads dropped because the queue was full.")
+	fmt.Fprintln(w, "# TYPE alertmanager_discord_queue_dropped_total counter")
+	for _, q := range snapshot {
+		fmt.Fprintf(w, "alertmanager_discord_queue_dropped_total{webhook=%q} %d\n", q.label(), atomic.LoadUint64(&q.dropped))
+	}
+
+	fmt.Fprintln(w, "# HELP alertmanager_discord_queue_retries_total Delivery attempts that were retried.")
+	fmt.Fprintln(w, "# TYPE alertmanager_discord_queue_retries_total counter")
+	for _, q := range snapshot {
+		fmt.Fprintf(w, "alertmanager_discord_queue_retries_total{webhook=%q} %d\n", q.label(), atomic.LoadUint64(&q.retried))
+	}
+
+	fmt.Fprintln(w, "# HELP alertmanager_discord_queue_last_error Whether the queue has a recorded last error; the error text is a label.")
+	fmt.Fprintln(w, "# TYPE alertmanager_discord_queue_last_error gauge")
+	for _, q := range snapshot {
+		lastErr, _ := q.lastErr.Load().(string)
+		if lastErr == "" {
+			continue
+		}
+		fmt.Fprintf(w, "alertmanager_discord_queue_last_error{webhook=%q,error=%q} 1\n", q.label(), lastErr)
+	}
+}
+
+func (q *DeliveryQueue) label() string {
+	return redactWebhookURL(q.whURL)
+}
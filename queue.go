@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// queueConfig holds the limits every DeliveryQueue is built with. main sets
+// this once from flags before the first alert can create a queue.
+var queueConfig = struct {
+	maxDepth   int
+	maxRetries int
+	persistDir string
+}{maxDepth: 100, maxRetries: 5}
+
+// queueItem is one pending payload plus the groupKey Alertmanager's repeat
+// notifications reuse, so a newer snapshot can replace a stale one that's
+// still waiting to send.
+type queueItem struct {
+	GroupKey string          `json:"groupKey"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// DeliveryQueue serializes, paces and retries webhook deliveries for one
+// destination URL. Successive items sharing a GroupKey are collapsed to the
+// newest one, since Alertmanager resends the whole active set on every
+// notification: that applies both while an item is still waiting to send
+// and, via superseded, while it's already in flight and retrying.
+type DeliveryQueue struct {
+	whURL       string
+	maxDepth    int
+	maxRetries  int
+	persistFile string
+
+	mu      sync.Mutex
+	order   []string
+	pending map[string]queueItem
+
+	wake chan struct{}
+
+	depth   int64
+	dropped uint64
+	retried uint64
+	lastErr atomic.Value // string
+}
+
+var (
+	queuesMu sync.Mutex
+	queues   = map[string]*DeliveryQueue{}
+)
+
+// getOrCreateQueue returns the DeliveryQueue for whURL, creating (and
+// starting) it on first use.
+func getOrCreateQueue(whURL string) *DeliveryQueue {
+	queuesMu.Lock()
+	defer queuesMu.Unlock()
+	if q, ok := queues[whURL]; ok {
+		return q
+	}
+	q := newDeliveryQueue(whURL)
+	queues[whURL] = q
+	go q.run()
+	return q
+}
+
+func newDeliveryQueue(whURL string) *DeliveryQueue {
+	q := &DeliveryQueue{
+		whURL:      whURL,
+		maxDepth:   queueConfig.maxDepth,
+		maxRetries: queueConfig.maxRetries,
+		pending:    map[string]queueItem{},
+		wake:       make(chan struct{}, 1),
+	}
+	if queueConfig.persistDir != "" {
+		q.persistFile = filepath.Join(queueConfig.persistDir, webhookDigest(whURL)+".json")
+		q.loadPersisted()
+	}
+	return q
+}
+
+// enqueueWebhook marshals payload and hands it to whURL's DeliveryQueue.
+func enqueueWebhook(whURL, groupKey string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	if *debug {
+		fmt.Println("Queue webhook:", string(body))
+	}
+	getOrCreateQueue(whURL).Enqueue(groupKey, body)
+	return nil
+}
+
+// Enqueue adds body under groupKey, replacing any same-groupKey payload
+// still waiting to send, and drops the oldest pending item if the queue is
+// already at maxDepth.
+func (q *DeliveryQueue) Enqueue(groupKey string, body []byte) {
+	q.mu.Lock()
+	if _, exists := q.pending[groupKey]; !exists {
+		if len(q.order) >= q.maxDepth {
+			oldest := q.order[0]
+			q.order = q.order[1:]
+			delete(q.pending, oldest)
+			atomic.AddUint64(&q.dropped, 1)
+			log.Printf("queue %s: dropped oldest pending item (queue full at %d)", redactWebhookURL(q.whURL), q.maxDepth)
+		}
+		q.order = append(q.order, groupKey)
+	}
+	q.pending[groupKey] = queueItem{GroupKey: groupKey, Payload: append([]byte(nil), body...)}
+	atomic.StoreInt64(&q.depth, int64(len(q.order)))
+	q.persistLocked()
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run drains the queue until the process exits, pacing itself off whatever
+// the last response told it about the destination's rate-limit bucket.
+func (q *DeliveryQueue) run() {
+	for {
+		q.mu.Lock()
+		if len(q.order) == 0 {
+			q.mu.Unlock()
+			<-q.wake
+			continue
+		}
+		groupKey := q.order[0]
+		q.order = q.order[1:]
+		item := q.pending[groupKey]
+		delete(q.pending, groupKey)
+		atomic.StoreInt64(&q.depth, int64(len(q.order)))
+		q.persistLocked()
+		q.mu.Unlock()
+
+		if pace := q.sendWithRetry(item); pace > 0 {
+			time.Sleep(pace)
+		}
+	}
+}
+
+// sendWithRetry POSTs item, retrying 5xx/429 responses with exponential
+// backoff and jitter up to maxRetries. It returns how long to wait before
+// the next send, derived from the response's rate-limit headers.
+func (q *DeliveryQueue) sendWithRetry(item queueItem) time.Duration {
+	backoff := time.Second
+	for attempt := 0; attempt <= q.maxRetries; attempt++ {
+		if attempt > 0 && q.superseded(item.GroupKey) {
+			log.Printf("queue %s: dropping stale retry for %s, a newer snapshot is already queued", redactWebhookURL(q.whURL), item.GroupKey)
+			return 0
+		}
+
+		resp, err := http.Post(q.whURL, "application/json", bytes.NewReader(item.Payload))
+		if err != nil {
+			q.recordError(err)
+			atomic.AddUint64(&q.retried, 1)
+			time.Sleep(jitter(backoff))
+			backoff *= 2
+			continue
+		}
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			q.recordError(fmt.Errorf("status %d: %s", resp.StatusCode, string(body)))
+			atomic.AddUint64(&q.retried, 1)
+			if wait := parseRetryAfter(resp.Header.Get("Retry-After")); wait > 0 {
+				time.Sleep(wait)
+			} else {
+				time.Sleep(jitter(backoff))
+				backoff *= 2
+			}
+			continue
+		case resp.StatusCode >= 400:
+			q.recordError(fmt.Errorf("status %d: %s", resp.StatusCode, string(body)))
+			return 0
+		default:
+			return paceFromHeaders(resp.Header)
+		}
+	}
+	log.Printf("queue %s: giving up after %d attempts", redactWebhookURL(q.whURL), q.maxRetries+1)
+	return 0
+}
+
+// superseded reports whether groupKey has a fresher payload already waiting
+// in pending, i.e. an Enqueue arrived for it while the current item was
+// in-flight. sendWithRetry uses this to abandon a stale retry instead of
+// burning backoff time delivering data a newer snapshot has already replaced.
+func (q *DeliveryQueue) superseded(groupKey string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.pending[groupKey]
+	return ok
+}
+
+func (q *DeliveryQueue) recordError(err error) {
+	log.Printf("queue %s: %v", redactWebhookURL(q.whURL), err)
+	q.lastErr.Store(err.Error())
+}
+
+// persistLocked snapshots the pending queue to persistFile. Callers must
+// hold q.mu. A no-op when persistence isn't configured.
+func (q *DeliveryQueue) persistLocked() {
+	if q.persistFile == "" {
+		return
+	}
+	items := make([]queueItem, 0, len(q.order))
+	for _, key := range q.order {
+		items = append(items, q.pending[key])
+	}
+	body, err := json.Marshal(items)
+	if err != nil {
+		log.Printf("queue %s: failed to marshal persisted snapshot: %v", redactWebhookURL(q.whURL), err)
+		return
+	}
+	tmp := q.persistFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, body, 0o600); err != nil {
+		log.Printf("queue %s: failed to write persisted snapshot: %v", redactWebhookURL(q.whURL), err)
+		return
+	}
+	if err := os.Rename(tmp, q.persistFile); err != nil {
+		log.Printf("queue %s: failed to commit persisted snapshot: %v", redactWebhookURL(q.whURL), err)
+	}
+}
+
+// loadPersisted restores a queue snapshot left behind by a previous run, so
+// a restart doesn't lose whatever was still queued.
+func (q *DeliveryQueue) loadPersisted() {
+	body, err := ioutil.ReadFile(q.persistFile)
+	if err != nil {
+		return
+	}
+	var items []queueItem
+	if err := json.Unmarshal(body, &items); err != nil {
+		log.Printf("queue %s: ignoring unreadable persisted snapshot: %v", redactWebhookURL(q.whURL), err)
+		return
+	}
+	for _, item := range items {
+		q.order = append(q.order, item.GroupKey)
+		q.pending[item.GroupKey] = item
+	}
+	atomic.StoreInt64(&q.depth, int64(len(q.order)))
+}
+
+// parseRetryAfter reads a Discord/HTTP Retry-After header, which is either a
+// number of seconds or an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// paceFromHeaders reads Discord's X-RateLimit-Remaining/X-RateLimit-Reset-After
+// headers so the queue can wait out the bucket before its next send instead
+// of bursting into a 429.
+func paceFromHeaders(h http.Header) time.Duration {
+	remaining := h.Get("X-RateLimit-Remaining")
+	if remaining != "0" {
+		return 0
+	}
+	resetAfter := h.Get("X-RateLimit-Reset-After")
+	if resetAfter == "" {
+		return 0
+	}
+	secs, err := strconv.ParseFloat(resetAfter, 64)
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+// jitter adds up to d/2 of random jitter to d, so many queues backing off at
+// once don't retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// webhookDigest derives a filesystem-safe, secret-free name for whURL's
+// persisted snapshot file.
+func webhookDigest(whURL string) string {
+	sum := sha1.Sum([]byte(whURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// redactWebhookURL renders whURL as a log/metrics-safe label: host plus a
+// short digest, never the path (which carries the webhook's secret token).
+func redactWebhookURL(whURL string) string {
+	u, err := url.Parse(whURL)
+	host := "unknown"
+	if err == nil {
+		host = u.Host
+	}
+	return fmt.Sprintf("%s-%s", host, webhookDigest(whURL)[:8])
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	future := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name string
+		in   string
+		want time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "5", 5 * time.Second},
+		{"past http date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0},
+		{"future http date", future, 90 * time.Second},
+		{"garbage", "not-a-duration", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.in)
+			if tt.name == "future http date" {
+				// http.TimeFormat truncates to the second, so allow a little slop.
+				if got <= 0 || got > tt.want+time.Second {
+					t.Fatalf("parseRetryAfter(%q) = %v, want ~%v", tt.in, got, tt.want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Fatalf("parseRetryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaceFromHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		hdr  http.Header
+		want time.Duration
+	}{
+		{"remaining not exhausted", http.Header{"X-Ratelimit-Remaining": {"3"}}, 0},
+		{"exhausted no reset-after", http.Header{"X-Ratelimit-Remaining": {"0"}}, 0},
+		{
+			"exhausted with reset-after",
+			http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset-After": {"1.5"}},
+			1500 * time.Millisecond,
+		},
+		{
+			"malformed reset-after",
+			http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset-After": {"nope"}},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paceFromHeaders(tt.hdr); got != tt.want {
+				t.Fatalf("paceFromHeaders(%v) = %v, want %v", tt.hdr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnqueueCollapsesSameGroupKey(t *testing.T) {
+	q := newDeliveryQueue("http://example.invalid/webhook")
+
+	q.Enqueue("group-a", []byte(`"first"`))
+	q.Enqueue("group-a", []byte(`"second"`))
+
+	if len(q.order) != 1 {
+		t.Fatalf("order = %v, want exactly one entry for the collapsed groupKey", q.order)
+	}
+	item := q.pending["group-a"]
+	if string(item.Payload) != `"second"` {
+		t.Fatalf("pending payload = %s, want the newest enqueued payload", item.Payload)
+	}
+}
+
+func TestEnqueueDropsOldestWhenFull(t *testing.T) {
+	q := newDeliveryQueue("http://example.invalid/webhook")
+	q.maxDepth = 2
+
+	q.Enqueue("group-a", []byte(`"a"`))
+	q.Enqueue("group-b", []byte(`"b"`))
+	q.Enqueue("group-c", []byte(`"c"`))
+
+	if len(q.order) != 2 {
+		t.Fatalf("order = %v, want maxDepth=2 entries after overflow", q.order)
+	}
+	if _, ok := q.pending["group-a"]; ok {
+		t.Fatalf("pending still has the oldest groupKey, want it dropped")
+	}
+	if q.dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", q.dropped)
+	}
+}
+
+func TestSupersededReflectsArrivalDuringInFlight(t *testing.T) {
+	q := newDeliveryQueue("http://example.invalid/webhook")
+
+	if q.superseded("group-a") {
+		t.Fatalf("superseded(%q) = true before any enqueue, want false", "group-a")
+	}
+
+	// Simulate run() having dequeued group-a for sendWithRetry: it's no
+	// longer in pending/order, i.e. it's in flight.
+	q.Enqueue("group-a", []byte(`"first"`))
+	q.mu.Lock()
+	delete(q.pending, "group-a")
+	q.order = nil
+	q.mu.Unlock()
+
+	if q.superseded("group-a") {
+		t.Fatalf("superseded(%q) = true with nothing re-enqueued, want false", "group-a")
+	}
+
+	q.Enqueue("group-a", []byte(`"fresher"`))
+	if !q.superseded("group-a") {
+		t.Fatalf("superseded(%q) = false after a fresher enqueue, want true", "group-a")
+	}
+}
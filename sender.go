@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Sender delivers a rendered Alertmanager notification to one chat backend.
+// Each chat integration (Discord, Slack, Teams, ...) implements this against
+// its own payload shape.
+type Sender interface {
+	// Name identifies the sender for logging, e.g. "discord".
+	Name() string
+	// Send delivers amo to the destination configured on this Sender.
+	Send(ctx context.Context, amo *alertManOut) error
+}
+
+// SinkConfig describes one configured destination: which Sender implementation
+// to use, where to send it, and which alerts it should receive. A zero-value
+// field means "don't filter on this".
+type SinkConfig struct {
+	Scheme      string            `json:"scheme" yaml:"scheme"`
+	URL         string            `json:"url" yaml:"url"`
+	Receiver    string            `json:"receiver,omitempty" yaml:"receiver,omitempty"`
+	Status      string            `json:"status,omitempty" yaml:"status,omitempty"`
+	Match       map[string]string `json:"match,omitempty" yaml:"match,omitempty"`
+	TemplateSet string            `json:"templateSet,omitempty" yaml:"templateSet,omitempty"`
+}
+
+// matches reports whether amo passes this sink's receiver/status/label filters.
+func (c SinkConfig) matches(amo *alertManOut) bool {
+	if c.Receiver != "" && c.Receiver != amo.Receiver {
+		return false
+	}
+	if c.Status != "" && c.Status != amo.Status {
+		return false
+	}
+	if len(c.Match) == 0 {
+		return true
+	}
+	for _, alert := range amo.Alerts {
+		if labelsMatch(alert.Labels, c.Match) {
+			return true
+		}
+	}
+	return false
+}
+
+func labelsMatch(labels, want map[string]string) bool {
+	for k, v := range want {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// senderFactory builds a Sender from its SinkConfig. Built-in chat backends
+// register one of these against their scheme via registerSender's init().
+type senderFactory func(sc SinkConfig) (Sender, error)
+
+var senderFactories = map[string]senderFactory{}
+
+// registerSender makes scheme (e.g. "discord") available as a SinkConfig.Scheme.
+func registerSender(scheme string, factory senderFactory) {
+	senderFactories[scheme] = factory
+}
+
+// registeredSink pairs a live Sender with the filters that select it.
+type registeredSink struct {
+	SinkConfig
+	sender Sender
+}
+
+// Registry fans an Alertmanager payload out to every configured sink whose
+// filters match, concurrently.
+type Registry struct {
+	sinks []registeredSink
+}
+
+// NewRegistry builds a Registry from sinks, looking up each sink's Sender
+// implementation by SinkConfig.Scheme.
+func NewRegistry(sinks []SinkConfig) (*Registry, error) {
+	r := &Registry{}
+	for _, sc := range sinks {
+		factory, ok := senderFactories[sc.Scheme]
+		if !ok {
+			return nil, fmt.Errorf("unknown sender scheme %q for %s", sc.Scheme, sc.URL)
+		}
+		s, err := factory(sc)
+		if err != nil {
+			return nil, fmt.Errorf("configure %s sender %s: %w", sc.Scheme, sc.URL, err)
+		}
+		r.sinks = append(r.sinks, registeredSink{SinkConfig: sc, sender: s})
+	}
+	return r, nil
+}
+
+// Empty reports whether the registry has no sinks configured.
+func (r *Registry) Empty() bool {
+	return len(r.sinks) == 0
+}
+
+// Dispatch sends amo to every sink whose filters match, in parallel, and logs
+// (rather than returns) per-sink failures so one broken sink can't stop the
+// others from receiving the alert.
+func (r *Registry) Dispatch(ctx context.Context, amo *alertManOut) {
+	var wg sync.WaitGroup
+	for _, rs := range r.sinks {
+		if !rs.matches(amo) {
+			continue
+		}
+		wg.Add(1)
+		go func(rs registeredSink) {
+			defer wg.Done()
+			if err := rs.sender.Send(ctx, amo); err != nil {
+				log.Printf("%s sender failed for %s: %v", rs.sender.Name(), rs.URL, err)
+			}
+		}(rs)
+	}
+	wg.Wait()
+}
+
+// amoColor picks a single representative severity color for amo by taking
+// the worst-case status across its alerts, for chat backends (Slack, Teams,
+// Mattermost) whose payload only carries one color per message.
+func amoColor(amo *alertManOut) int {
+	best := -1
+	color := ColorGrey
+	for _, alert := range amo.Alerts {
+		status := alert.Status
+		var rank int
+		switch alert.Status {
+		case "firing":
+			if sev, ok := alert.Labels["severity"]; ok {
+				status = sev
+			}
+			rank = severityRank(status)
+		case "resolved":
+			status = "normal"
+			rank = 0
+		}
+		if rank > best {
+			best = rank
+			color = getSeverityColor(status)
+			if status == "normal" {
+				color = ColorGreen
+			}
+		}
+	}
+	return color
+}
+
+// severityRank orders severities worst-first so amoColor can pick the single
+// most urgent color to represent a batch of alerts. Unrecognized severities
+// (including a firing alert with no severity label at all) rank alongside
+// "critical" because getSeverityColor falls back to ColorRed for the same
+// input, so the two stay consistent rather than an unlabeled alert losing
+// out to an actual "warning" in the same batch.
+func severityRank(severity string) int {
+	switch severity {
+	case "warning":
+		return 2
+	case "info":
+		return 1
+	default:
+		return 3
+	}
+}
+
+// colorToHex renders a Discord-style 0xRRGGBB int as a "#RRGGBB" string for
+// backends (Slack, Mattermost) that take web color syntax instead.
+func colorToHex(color int) string {
+	return fmt.Sprintf("#%06X", color)
+}
+
+// renderPlainText builds a Markdown-ish summary of amo shared by chat
+// backends that don't have a rich-embed concept of their own (Slack, Teams,
+// Mattermost, DingTalk, Feishu, WeCom). Discord keeps its own embed builder
+// in sender_discord.go since it supports structured fields/colors natively.
+func renderPlainText(amo *alertManOut) string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("=== Alert: %s - %s ===", amo.Receiver, amo.GroupLabels.Alertname))
+	for _, alert := range amo.Alerts {
+		status := alert.Status
+		if alert.Status == "firing" {
+			if s, ok := alert.Labels["severity"]; ok {
+				status = s
+			}
+		} else if alert.Status == "resolved" {
+			status = "normal"
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s", status, alert.Annotations.Summary))
+		if alert.Annotations.Description != "" {
+			lines = append(lines, alert.Annotations.Description)
+		}
+		for k, v := range alert.Labels {
+			if k == "value" || strings.HasPrefix(k, "metrics_") {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("- %s: %s", k, v))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func init() {
+	registerSender("dingtalk", newDingTalkSender)
+}
+
+// dingTalkOut is a DingTalk custom-robot markdown message.
+// https://open.dingtalk.com/document/robots/custom-robot-access
+type dingTalkOut struct {
+	MsgType  string          `json:"msgtype"`
+	Markdown dingTalkContent `json:"markdown"`
+}
+
+type dingTalkContent struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// DingTalkSender posts alerts to a DingTalk custom robot webhook.
+type DingTalkSender struct {
+	whURL string
+}
+
+func newDingTalkSender(sc SinkConfig) (Sender, error) {
+	return &DingTalkSender{whURL: sc.URL}, nil
+}
+
+func (s *DingTalkSender) Name() string {
+	return "dingtalk"
+}
+
+func (s *DingTalkSender) Send(ctx context.Context, amo *alertManOut) error {
+	out := dingTalkOut{
+		MsgType: "markdown",
+		Markdown: dingTalkContent{
+			Title: fmt.Sprintf("%s - %s", amo.Receiver, amo.GroupLabels.Alertname),
+			Text:  renderPlainText(amo),
+		},
+	}
+	return enqueueWebhook(s.whURL, amo.GroupKey, out)
+}
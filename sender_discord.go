@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func init() {
+	registerSender("discord", newDiscordSender)
+}
+
+// discordBot is the optional interactive bot session; nil means every
+// DiscordSender posts plain webhook messages with no buttons.
+var discordBot *Bot
+
+// discordGrafanaURLTemplate, discordSeverityIcons and discordEmbedMaxFields are
+// set from their respective flags in main() before the registry is built.
+var (
+	discordGrafanaURLTemplate string
+	discordSeverityIcons      map[string]string
+	discordEmbedMaxFields     = 25
+)
+
+type discordOut struct {
+	Content string         `json:"content"`
+	Embeds  []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	URL         string                 `json:"url,omitempty"`
+	Color       int                    `json:"color"`
+	Timestamp   string                 `json:"timestamp,omitempty"`
+	Author      *discordEmbedAuthor    `json:"author,omitempty"`
+	Footer      *discordEmbedFooter    `json:"footer,omitempty"`
+	Thumbnail   *discordEmbedThumbnail `json:"thumbnail,omitempty"`
+	Image       *discordEmbedImage     `json:"image,omitempty"`
+	Fields      []discordEmbedField    `json:"fields"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type discordEmbedAuthor struct {
+	Name string `json:"name"`
+}
+
+type discordEmbedFooter struct {
+	Text string `json:"text"`
+}
+
+type discordEmbedThumbnail struct {
+	URL string `json:"url"`
+}
+
+type discordEmbedImage struct {
+	URL string `json:"url"`
+}
+
+// DiscordSender posts alerts to a Discord incoming webhook, batching alerts
+// into embeds MaxDiscordEmbed at a time (Discord rejects more per message).
+// sinkConfig carries the per-sink template set override, if any.
+type DiscordSender struct {
+	whURL      string
+	sinkConfig SinkConfig
+}
+
+func newDiscordSender(sc SinkConfig) (Sender, error) {
+	if err := checkWhURL(sc.URL); err != nil {
+		return nil, err
+	}
+	return &DiscordSender{whURL: sc.URL, sinkConfig: sc}, nil
+}
+
+func (s *DiscordSender) Name() string {
+	return "discord"
+}
+
+func (s *DiscordSender) Send(ctx context.Context, amo *alertManOut) error {
+	content := fmt.Sprintf("=== Alert: %s - %s ===", amo.Receiver, amo.GroupLabels.Alertname)
+	embeds := buildDiscordEmbeds(amo)
+
+	if ts := resolveTemplateSet(s.sinkConfig, amo.Receiver); ts != nil {
+		renderedContent, err := renderDiscordContent(ts, amo)
+		if err != nil {
+			return fmt.Errorf("render content template: %w", err)
+		}
+		content = renderedContent
+
+		renderedEmbeds := make([]discordEmbed, 0, len(amo.Alerts))
+		for _, alert := range amo.Alerts {
+			embed, err := renderDiscordEmbed(ts, amo, alert)
+			if err != nil {
+				return fmt.Errorf("render embed template: %w", err)
+			}
+			renderedEmbeds = append(renderedEmbeds, embed)
+		}
+		embeds = renderedEmbeds
+	}
+
+	DO := discordOut{Content: content}
+	if len(embeds) > MaxDiscordEmbed {
+		for i := 0; i < len(embeds); i += MaxDiscordEmbed {
+			end := i + MaxDiscordEmbed
+			if end > len(embeds) {
+				end = len(embeds)
+			}
+			DO.Embeds = embeds[i:end]
+			if err := s.post(amo, DO, i/MaxDiscordEmbed); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	DO.Embeds = embeds
+	return s.post(amo, DO, 0)
+}
+
+// post delivers DO either through the per-webhook delivery queue (the
+// historical path, now paced and retried instead of fire-and-forget) or,
+// when a Discord bot is configured, through the bot's session so the
+// message carries interactive silence/ack buttons. The bot path bypasses
+// the queue: discordgo already paces its own requests against Discord's
+// rate-limit buckets. chunk distinguishes the >10-embed overflow messages
+// of a single Send call so they don't collapse into each other.
+func (s *DiscordSender) post(amo *alertManOut, DO discordOut, chunk int) error {
+	if discordBot == nil {
+		groupKey := amo.GroupKey
+		if chunk > 0 {
+			groupKey = fmt.Sprintf("%s#%d", amo.GroupKey, chunk)
+		}
+		return enqueueWebhook(s.whURL, groupKey, DO)
+	}
+	if amo.Status == "resolved" {
+		discordBot.forget(amo.GroupKey)
+	} else {
+		discordBot.remember(amo.GroupKey, firstAlertLabels(amo))
+	}
+	return discordBot.sendWithComponents(s.whURL, DO, amo.GroupKey)
+}
+
+// firstAlertLabels returns the labels of amo's first alert, the label set
+// used to build a silence matcher when a button is clicked later.
+func firstAlertLabels(amo *alertManOut) map[string]string {
+	if len(amo.Alerts) == 0 {
+		return nil
+	}
+	return amo.Alerts[0].Labels
+}
+
+// embedIconKey returns the discordSeverityIcons lookup key for alert: its
+// severity label when firing, or "resolved" when resolved. Kept distinct
+// from the "normal" status text shown in an embed's title, since the
+// -discord.severity-icons flag is documented in terms of Alertmanager's own
+// status/severity vocabulary.
+func embedIconKey(alert alertManAlert) string {
+	switch alert.Status {
+	case "firing":
+		if s, ok := alert.Labels["severity"]; ok {
+			return s
+		}
+		return alert.Status
+	case "resolved":
+		return "resolved"
+	default:
+		return alert.Status
+	}
+}
+
+// decorateEmbed fills in the chrome shared by every Discord embed regardless
+// of whether it came from buildDiscordEmbeds' hardcoded layout or an
+// operator template: author, footer, severity thumbnail, Grafana/generator
+// link and timestamp. Title, description, color and fields are left to the
+// caller, since those vary between the two paths.
+func decorateEmbed(embed *discordEmbed, amo *alertManOut, alert alertManAlert) {
+	embed.Author = &discordEmbedAuthor{Name: amo.Receiver}
+	embed.Footer = &discordEmbedFooter{Text: footerText(amo)}
+	if icon := discordSeverityIcons[embedIconKey(alert)]; icon != "" {
+		embed.Thumbnail = &discordEmbedThumbnail{URL: icon}
+	}
+	embed.URL = embedURL(alert)
+	// Discord renders the timestamp field in the viewer's own timezone, so
+	// the alert's RFC3339 StartsAt can be used as-is with no TZ dance.
+	if startAt, err := time.Parse(time.RFC3339, alert.StartsAt); err == nil {
+		embed.Timestamp = startAt.Format(time.RFC3339)
+	}
+}
+
+func buildDiscordEmbeds(amo *alertManOut) []discordEmbed {
+	var embeds []discordEmbed
+	for _, alert := range amo.Alerts {
+		status := alert.Status
+		embed := discordEmbed{Color: ColorGrey}
+		switch alert.Status {
+		case "firing":
+			if s, ok := alert.Labels["severity"]; ok {
+				status = s
+			}
+			embed.Color = getSeverityColor(status)
+		case "resolved":
+			embed.Color = ColorGreen
+			status = "normal"
+		}
+		decorateEmbed(&embed, amo, alert)
+
+		startAt, _ := time.Parse(time.RFC3339, alert.StartsAt)
+		endAt, _ := time.Parse(time.RFC3339, alert.EndsAt)
+
+		embed.Title = fmt.Sprintf("[%s] %s", strings.ToUpper(status), alert.Annotations.Summary)
+
+		var fields []discordEmbedField
+		var metricsValue float64
+		var metricsConv string
+		for k, v := range alert.Labels {
+			if strings.HasPrefix(k, "metrics_") {
+				// ignore metrics
+				if k == "metrics_value" {
+					metricsValue, _ = strconv.ParseFloat(v, 64)
+				} else if k == "metrics_conv" {
+					metricsConv = v
+				}
+				continue
+			}
+			// Ignore some key
+			if k == "value" {
+				continue
+			}
+			fields = append(fields, discordEmbedField{Name: k, Value: v, Inline: true})
+		}
+		if status != "normal" {
+			fields = append(fields, discordEmbedField{Name: "value", Value: valueConv(metricsValue, metricsConv), Inline: true})
+		}
+		embed.Fields = capFields(fields, discordEmbedMaxFields)
+
+		description := strings.Split(alert.Annotations.Description, "\n")
+		for i, v := range description {
+			description[i] = fmt.Sprintf(": - %s", v)
+		}
+		var embedDescribe []string
+		eventTime := startAt
+		if endAt.After(startAt) {
+			eventTime = endAt
+		}
+		embedDescribe = append(embedDescribe, fmt.Sprintf("**⏰ Event Time:** %s", eventTime.Format(time.DateTime)))
+		embedDescribe = append(embedDescribe, "------")
+		embedDescribe = append(embedDescribe, fmt.Sprintf("**📖 Description:**\n%s", strings.Join(description, "\n")))
+		if endAt.After(startAt) {
+			embedDescribe = append(embedDescribe, "------")
+			embedDescribe = append(embedDescribe, fmt.Sprintf("**⏲️ Duration:** %s", endAt.Sub(startAt).String()))
+			embedDescribe = append(embedDescribe, fmt.Sprintf(": - **_Start:_** %s", startAt.Format(time.DateTime)))
+			embedDescribe = append(embedDescribe, fmt.Sprintf(": - **_End:_** %s", endAt.Format(time.DateTime)))
+		}
+		embed.Description = strings.Join(embedDescribe, "\n")
+		embeds = append(embeds, embed)
+	}
+	return embeds
+}
+
+// footerText builds an embed footer from amo's externalURL and groupKey, so
+// a Discord reader can trace a notification back to the Alertmanager that
+// sent it even without clicking through.
+func footerText(amo *alertManOut) string {
+	switch {
+	case amo.ExternalURL != "" && amo.GroupKey != "":
+		return fmt.Sprintf("%s | %s", amo.ExternalURL, amo.GroupKey)
+	case amo.ExternalURL != "":
+		return amo.ExternalURL
+	default:
+		return amo.GroupKey
+	}
+}
+
+// embedURL resolves the link an embed's title should point to: a Grafana
+// panel built from the alert's grafana_dashboard/grafana_panel labels via
+// -discord.grafana-url-template, falling back to the alert's generatorURL.
+func embedURL(alert alertManAlert) string {
+	dashboard, hasDashboard := alert.Labels["grafana_dashboard"]
+	panel, hasPanel := alert.Labels["grafana_panel"]
+	if discordGrafanaURLTemplate != "" && hasDashboard && hasPanel {
+		return fmt.Sprintf(discordGrafanaURLTemplate, dashboard, panel)
+	}
+	return alert.GeneratorURL
+}
+
+// parseSeverityIcons parses a comma-separated "severity=iconURL" list (the
+// -discord.severity-icons flag) into a lookup map, trimming whitespace and
+// dropping malformed entries.
+func parseSeverityIcons(s string) map[string]string {
+	icons := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		severity, iconURL, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || severity == "" || iconURL == "" {
+			continue
+		}
+		icons[severity] = iconURL
+	}
+	return icons
+}
+
+// capFields keeps at most max fields, collapsing any remainder into a single
+// overflow field so an alert with many labels stays under Discord's 25-field
+// embed limit. max<=0 disables the cap.
+func capFields(fields []discordEmbedField, max int) []discordEmbedField {
+	if max <= 0 || len(fields) <= max {
+		return fields
+	}
+	kept := append([]discordEmbedField{}, fields[:max-1]...)
+	var overflow strings.Builder
+	for _, f := range fields[max-1:] {
+		fmt.Fprintf(&overflow, "**%s:** %s\n", f.Name, f.Value)
+	}
+	kept = append(kept, discordEmbedField{Name: "…", Value: truncate(overflow.String(), 1024)})
+	return kept
+}
+
+// truncate shortens s to at most n bytes, Discord's per-field value limit,
+// backing off to the nearest rune boundary so a multi-byte label doesn't get
+// split mid-codepoint.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
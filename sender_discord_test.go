@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateDoesNotSplitMultiByteRune(t *testing.T) {
+	s := "label: 日本語のテキスト"
+	for n := 0; n <= len(s)+1; n++ {
+		got := truncate(s, n)
+		if len(got) > n {
+			t.Fatalf("truncate(%q, %d) = %q (%d bytes), want at most %d bytes", s, n, got, len(got), n)
+		}
+		if s[:len(got)] != got {
+			t.Fatalf("truncate(%q, %d) = %q, not a prefix of s", s, n, got)
+		}
+		if !utf8.ValidString(got) {
+			t.Fatalf("truncate(%q, %d) = %q, split a multi-byte rune", s, n, got)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "context"
+
+func init() {
+	registerSender("feishu", newFeishuSender)
+}
+
+// feishuOut is a Feishu/Lark custom-bot text message.
+// https://open.feishu.cn/document/client-docs/bot-v3/add-custom-bot
+type feishuOut struct {
+	MsgType string        `json:"msg_type"`
+	Content feishuContent `json:"content"`
+}
+
+type feishuContent struct {
+	Text string `json:"text"`
+}
+
+// FeishuSender posts alerts to a Feishu (Lark) custom bot webhook.
+type FeishuSender struct {
+	whURL string
+}
+
+func newFeishuSender(sc SinkConfig) (Sender, error) {
+	return &FeishuSender{whURL: sc.URL}, nil
+}
+
+func (s *FeishuSender) Name() string {
+	return "feishu"
+}
+
+func (s *FeishuSender) Send(ctx context.Context, amo *alertManOut) error {
+	out := feishuOut{
+		MsgType: "text",
+		Content: feishuContent{Text: renderPlainText(amo)},
+	}
+	return enqueueWebhook(s.whURL, amo.GroupKey, out)
+}
@@ -0,0 +1,41 @@
+package main
+
+import "context"
+
+func init() {
+	registerSender("mattermost", newMattermostSender)
+}
+
+// mattermostOut is a Mattermost incoming-webhook payload, which follows the
+// same attachment shape as Slack's.
+type mattermostOut struct {
+	Text        string                 `json:"text"`
+	Attachments []mattermostAttachment `json:"attachments,omitempty"`
+}
+
+type mattermostAttachment struct {
+	Color string `json:"color"`
+	Text  string `json:"text"`
+}
+
+// MattermostSender posts alerts to a Mattermost incoming webhook.
+type MattermostSender struct {
+	whURL string
+}
+
+func newMattermostSender(sc SinkConfig) (Sender, error) {
+	return &MattermostSender{whURL: sc.URL}, nil
+}
+
+func (s *MattermostSender) Name() string {
+	return "mattermost"
+}
+
+func (s *MattermostSender) Send(ctx context.Context, amo *alertManOut) error {
+	out := mattermostOut{
+		Attachments: []mattermostAttachment{
+			{Color: colorToHex(amoColor(amo)), Text: renderPlainText(amo)},
+		},
+	}
+	return enqueueWebhook(s.whURL, amo.GroupKey, out)
+}
@@ -0,0 +1,41 @@
+package main
+
+import "context"
+
+func init() {
+	registerSender("slack", newSlackSender)
+}
+
+// slackOut is a Slack incoming-webhook payload. Slack renders attachment
+// color as a strip on the left edge the same way Discord uses embed.Color.
+type slackOut struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color string `json:"color"`
+	Text  string `json:"text"`
+}
+
+// SlackSender posts alerts to a Slack incoming webhook.
+type SlackSender struct {
+	whURL string
+}
+
+func newSlackSender(sc SinkConfig) (Sender, error) {
+	return &SlackSender{whURL: sc.URL}, nil
+}
+
+func (s *SlackSender) Name() string {
+	return "slack"
+}
+
+func (s *SlackSender) Send(ctx context.Context, amo *alertManOut) error {
+	out := slackOut{
+		Attachments: []slackAttachment{
+			{Color: colorToHex(amoColor(amo)), Text: renderPlainText(amo)},
+		},
+	}
+	return enqueueWebhook(s.whURL, amo.GroupKey, out)
+}
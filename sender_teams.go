@@ -0,0 +1,41 @@
+package main
+
+import "context"
+
+func init() {
+	registerSender("teams", newTeamsSender)
+}
+
+// teamsOut is a Microsoft Teams connector MessageCard.
+// https://learn.microsoft.com/en-us/outlook/actionable-messages/message-card-reference
+type teamsOut struct {
+	Type       string `json:"@type"`
+	Context    string `json:"@context"`
+	ThemeColor string `json:"themeColor"`
+	Summary    string `json:"summary"`
+	Text       string `json:"text"`
+}
+
+// TeamsSender posts alerts to a Microsoft Teams incoming webhook connector.
+type TeamsSender struct {
+	whURL string
+}
+
+func newTeamsSender(sc SinkConfig) (Sender, error) {
+	return &TeamsSender{whURL: sc.URL}, nil
+}
+
+func (s *TeamsSender) Name() string {
+	return "teams"
+}
+
+func (s *TeamsSender) Send(ctx context.Context, amo *alertManOut) error {
+	out := teamsOut{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: colorToHex(amoColor(amo))[1:],
+		Summary:    amo.CommonAnnotations.Summary,
+		Text:       renderPlainText(amo),
+	}
+	return enqueueWebhook(s.whURL, amo.GroupKey, out)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestSinkConfigMatches(t *testing.T) {
+	amo := &alertManOut{
+		Receiver: "critical-pager",
+		Status:   "firing",
+		Alerts: []alertManAlert{
+			{Labels: map[string]string{"team": "sre", "severity": "critical"}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		sc   SinkConfig
+		want bool
+	}{
+		{"no filters", SinkConfig{}, true},
+		{"matching receiver", SinkConfig{Receiver: "critical-pager"}, true},
+		{"mismatched receiver", SinkConfig{Receiver: "other"}, false},
+		{"matching status", SinkConfig{Status: "firing"}, true},
+		{"mismatched status", SinkConfig{Status: "resolved"}, false},
+		{"matching label", SinkConfig{Match: map[string]string{"team": "sre"}}, true},
+		{"mismatched label", SinkConfig{Match: map[string]string{"team": "other"}}, false},
+		{"partial label match required", SinkConfig{Match: map[string]string{"team": "sre", "region": "us"}}, false},
+		{"receiver and label both matching", SinkConfig{Receiver: "critical-pager", Match: map[string]string{"severity": "critical"}}, true},
+		{"receiver matches but label doesn't", SinkConfig{Receiver: "critical-pager", Match: map[string]string{"severity": "warning"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sc.matches(amo); got != tt.want {
+				t.Fatalf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeSender records every amo it receives, optionally returning err.
+type fakeSender struct {
+	mu   sync.Mutex
+	name string
+	err  error
+	got  []*alertManOut
+}
+
+func (f *fakeSender) Name() string { return f.name }
+
+func (f *fakeSender) Send(_ context.Context, amo *alertManOut) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.got = append(f.got, amo)
+	return f.err
+}
+
+func TestRegistryDispatchOnlySendsToMatchingSinks(t *testing.T) {
+	registerSender("fake-dispatch-test", func(sc SinkConfig) (Sender, error) { return &fakeSender{name: sc.Scheme}, nil })
+
+	registry, err := NewRegistry([]SinkConfig{
+		{Scheme: "fake-dispatch-test", URL: "a", Receiver: "pager"},
+		{Scheme: "fake-dispatch-test", URL: "b", Receiver: "slack-warnings"},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+
+	amo := &alertManOut{Receiver: "pager"}
+	registry.Dispatch(context.Background(), amo)
+
+	wantHit := registry.sinks[0].sender.(*fakeSender)
+	wantMiss := registry.sinks[1].sender.(*fakeSender)
+
+	if len(wantHit.got) != 1 || wantHit.got[0] != amo {
+		t.Fatalf("matching sink got %v, want exactly one delivery of amo", wantHit.got)
+	}
+	if len(wantMiss.got) != 0 {
+		t.Fatalf("non-matching sink got %v, want no deliveries", wantMiss.got)
+	}
+}
+
+func TestRegistryDispatchContinuesAfterSenderError(t *testing.T) {
+	registerSender("fake-dispatch-err-test", func(sc SinkConfig) (Sender, error) {
+		return &fakeSender{name: sc.Scheme, err: errFakeSend}, nil
+	})
+
+	registry, err := NewRegistry([]SinkConfig{{Scheme: "fake-dispatch-err-test", URL: "a"}})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+
+	// Dispatch must not panic or block despite the sender failing.
+	registry.Dispatch(context.Background(), &alertManOut{})
+
+	got := registry.sinks[0].sender.(*fakeSender)
+	if len(got.got) != 1 {
+		t.Fatalf("sender got %d deliveries, want 1 even though Send returned an error", len(got.got))
+	}
+}
+
+func TestNewRegistryUnknownScheme(t *testing.T) {
+	if _, err := NewRegistry([]SinkConfig{{Scheme: "does-not-exist", URL: "a"}}); err == nil {
+		t.Fatal("NewRegistry with an unregistered scheme, want an error")
+	}
+}
+
+func TestSeverityRank(t *testing.T) {
+	tests := []struct {
+		severity string
+		want     int
+	}{
+		{"critical", 3},
+		{"warning", 2},
+		{"info", 1},
+		{"", 3},
+		{"firing", 3},
+		{"unknown-severity", 3},
+	}
+	for _, tt := range tests {
+		if got := severityRank(tt.severity); got != tt.want {
+			t.Errorf("severityRank(%q) = %d, want %d", tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestAmoColorPicksWorstCase(t *testing.T) {
+	tests := []struct {
+		name string
+		amo  *alertManOut
+		want int
+	}{
+		{
+			name: "unlabeled firing alongside a warning picks red, not orange",
+			amo: &alertManOut{Alerts: []alertManAlert{
+				{Status: "firing", Labels: map[string]string{"severity": "warning"}},
+				{Status: "firing", Labels: map[string]string{}},
+			}},
+			want: ColorRed,
+		},
+		{
+			name: "resolved alongside warning picks the warning's color",
+			amo: &alertManOut{Alerts: []alertManAlert{
+				{Status: "resolved"},
+				{Status: "firing", Labels: map[string]string{"severity": "warning"}},
+			}},
+			want: ColorOrange,
+		},
+		{
+			name: "all resolved picks green",
+			amo: &alertManOut{Alerts: []alertManAlert{
+				{Status: "resolved"},
+			}},
+			want: ColorGreen,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := amoColor(tt.amo); got != tt.want {
+				t.Fatalf("amoColor() = %#x, want %#x", got, tt.want)
+			}
+		})
+	}
+}
+
+var errFakeSend = fakeSendError("fake send error")
+
+type fakeSendError string
+
+func (e fakeSendError) Error() string { return string(e) }
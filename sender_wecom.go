@@ -0,0 +1,39 @@
+package main
+
+import "context"
+
+func init() {
+	registerSender("wecom", newWeComSender)
+}
+
+// weComOut is a WeCom (Enterprise WeChat) group-robot markdown message.
+// https://developer.work.weixin.qq.com/document/path/91770
+type weComOut struct {
+	MsgType  string       `json:"msgtype"`
+	Markdown weComContent `json:"markdown"`
+}
+
+type weComContent struct {
+	Content string `json:"content"`
+}
+
+// WeComSender posts alerts to a WeCom group robot webhook.
+type WeComSender struct {
+	whURL string
+}
+
+func newWeComSender(sc SinkConfig) (Sender, error) {
+	return &WeComSender{whURL: sc.URL}, nil
+}
+
+func (s *WeComSender) Name() string {
+	return "wecom"
+}
+
+func (s *WeComSender) Send(ctx context.Context, amo *alertManOut) error {
+	out := weComOut{
+		MsgType:  "markdown",
+		Markdown: weComContent{Content: renderPlainText(amo)},
+	}
+	return enqueueWebhook(s.whURL, amo.GroupKey, out)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateData is the context exposed to operator-authored templates: the
+// whole incoming alert plus the derived values the built-in layout computes
+// inline today, so a template can reproduce or restyle them without Go code.
+type templateData struct {
+	Alert          alertManAlert
+	Group          *alertManOut
+	Severity       string
+	StatusUpper    string
+	EventTime      time.Time
+	Duration       time.Duration
+	ValueFormatted string
+}
+
+// templateFuncs are the helper functions available inside every operator
+// template, on top of text/template's builtins.
+var templateFuncs = template.FuncMap{
+	"toUpper": strings.ToUpper,
+	"title":   strings.Title,
+	"join":    strings.Join,
+	"reReplaceAll": func(pattern, repl, src string) string {
+		return regexp.MustCompile(pattern).ReplaceAllString(src, repl)
+	},
+	"humanizeDuration": func(d time.Duration) string { return d.String() },
+	"formatTime": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"valueConv": valueConv,
+}
+
+// TemplateSet is one named collection of operator templates used to render a
+// Discord message: the outer content line, an embed title/description per
+// alert, and a repeatable embed.field block executed once per label.
+type TemplateSet struct {
+	Name        string
+	content     *template.Template
+	embedTitle  *template.Template
+	embedDesc   *template.Template
+	embedFields *template.Template
+}
+
+// LoadTemplateSets reads one TemplateSet per immediate subdirectory of dir.
+// Each subdirectory must contain content.tmpl, embed.title.tmpl and
+// embed.description.tmpl; embed.field.tmpl is optional and, when present, is
+// executed once per label to build that alert's embed fields. embed.field.tmpl
+// must render as a single "name\tvalue" line (tab-separated); a rendered
+// output with no tab is dropped (and logged) rather than guessed at.
+func LoadTemplateSets(dir string) (map[string]*TemplateSet, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read templates dir: %w", err)
+	}
+	sets := map[string]*TemplateSet{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		ts, err := loadTemplateSet(entry.Name(), filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sets[ts.Name] = ts
+	}
+	return sets, nil
+}
+
+func loadTemplateSet(name, dir string) (*TemplateSet, error) {
+	ts := &TemplateSet{Name: name}
+	var err error
+	if ts.content, err = parseTemplateFile(filepath.Join(dir, "content.tmpl")); err != nil {
+		return nil, fmt.Errorf("template set %s: %w", name, err)
+	}
+	if ts.embedTitle, err = parseTemplateFile(filepath.Join(dir, "embed.title.tmpl")); err != nil {
+		return nil, fmt.Errorf("template set %s: %w", name, err)
+	}
+	if ts.embedDesc, err = parseTemplateFile(filepath.Join(dir, "embed.description.tmpl")); err != nil {
+		return nil, fmt.Errorf("template set %s: %w", name, err)
+	}
+	fieldFile := filepath.Join(dir, "embed.field.tmpl")
+	if _, statErr := os.Stat(fieldFile); statErr == nil {
+		if ts.embedFields, err = parseTemplateFile(fieldFile); err != nil {
+			return nil, fmt.Errorf("template set %s: %w", name, err)
+		}
+	}
+	return ts, nil
+}
+
+func parseTemplateFile(path string) (*template.Template, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(b))
+}
+
+// newTemplateData derives the template context for a single alert within amo.
+func newTemplateData(amo *alertManOut, alert alertManAlert) templateData {
+	status := alert.Status
+	severity := status
+	if alert.Status == "firing" {
+		if sev, ok := alert.Labels["severity"]; ok {
+			severity = sev
+		}
+	} else if alert.Status == "resolved" {
+		severity = "normal"
+	}
+
+	// Discord renders timestamps in the viewer's own timezone, so the parsed
+	// RFC3339 values can be used as-is with no TZ env / LoadLocation dance.
+	startAt, _ := time.Parse(time.RFC3339, alert.StartsAt)
+	endAt, _ := time.Parse(time.RFC3339, alert.EndsAt)
+	eventTime := startAt
+	var duration time.Duration
+	if endAt.After(startAt) {
+		eventTime = endAt
+		duration = endAt.Sub(startAt)
+	}
+
+	var value float64
+	var conv string
+	if v, ok := alert.Labels["metrics_value"]; ok {
+		value, _ = strconv.ParseFloat(v, 64)
+	}
+	if c, ok := alert.Labels["metrics_conv"]; ok {
+		conv = c
+	}
+
+	return templateData{
+		Alert:          alert,
+		Group:          amo,
+		Severity:       severity,
+		StatusUpper:    strings.ToUpper(severity),
+		EventTime:      eventTime,
+		Duration:       duration,
+		ValueFormatted: valueConv(value, conv),
+	}
+}
+
+// renderDiscordEmbed runs ts against alert, building one Discord embed the
+// way buildDiscordEmbeds does for the hardcoded default layout.
+func renderDiscordEmbed(ts *TemplateSet, amo *alertManOut, alert alertManAlert) (discordEmbed, error) {
+	data := newTemplateData(amo, alert)
+
+	title, err := execTemplate(ts.embedTitle, data)
+	if err != nil {
+		return discordEmbed{}, fmt.Errorf("embed.title: %w", err)
+	}
+	desc, err := execTemplate(ts.embedDesc, data)
+	if err != nil {
+		return discordEmbed{}, fmt.Errorf("embed.description: %w", err)
+	}
+
+	embed := discordEmbed{
+		Title:       title,
+		Description: desc,
+		Color:       getSeverityColor(data.Severity),
+	}
+	if data.Severity == "normal" {
+		embed.Color = ColorGreen
+	}
+	decorateEmbed(&embed, amo, alert)
+
+	var fields []discordEmbedField
+	if ts.embedFields != nil {
+		for k, v := range alert.Labels {
+			if k == "value" || strings.HasPrefix(k, "metrics_") {
+				continue
+			}
+			out, err := execTemplate(ts.embedFields, struct {
+				templateData
+				Label string
+				Value string
+			}{data, k, v})
+			if err != nil {
+				return discordEmbed{}, fmt.Errorf("embed.field: %w", err)
+			}
+			name, value, ok := strings.Cut(out, "\t")
+			if !ok {
+				log.Printf("template embed.field: dropping field for label %q, rendered output %q has no tab separator between name and value", k, out)
+				continue
+			}
+			fields = append(fields, discordEmbedField{Name: name, Value: value})
+		}
+	}
+	embed.Fields = capFields(fields, discordEmbedMaxFields)
+	return embed, nil
+}
+
+// renderDiscordContent runs ts's content template against amo's first alert,
+// matching the single "=== Alert: ... ===" line the default layout sends.
+func renderDiscordContent(ts *TemplateSet, amo *alertManOut) (string, error) {
+	data := templateData{Group: amo}
+	if len(amo.Alerts) > 0 {
+		data = newTemplateData(amo, amo.Alerts[0])
+	}
+	return execTemplate(ts.content, data)
+}
+
+// discordTemplateSets holds every TemplateSet loaded from -templates.dir, or
+// nil if no directory was configured — in which case DiscordSender falls back
+// to buildDiscordEmbeds, the embedded default that reproduces the historical
+// hardcoded layout so existing users see no change.
+var discordTemplateSets map[string]*TemplateSet
+
+// receiverTemplateSets maps an Alertmanager receiver name to the template set
+// it should render with, when a sink doesn't pin one explicitly.
+var receiverTemplateSets map[string]string
+
+// resolveTemplateSet picks the TemplateSet for sc, preferring its own
+// TemplateSet override, then the receiver's configured default, then the
+// "default" set if one was loaded. A nil result means: use the built-in
+// layout.
+func resolveTemplateSet(sc SinkConfig, receiver string) *TemplateSet {
+	if discordTemplateSets == nil {
+		return nil
+	}
+	if sc.TemplateSet != "" {
+		return discordTemplateSets[sc.TemplateSet]
+	}
+	if name, ok := receiverTemplateSets[receiver]; ok {
+		return discordTemplateSets[name]
+	}
+	return discordTemplateSets["default"]
+}
+
+func execTemplate(t *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}